@@ -0,0 +1,34 @@
+package image
+
+import "github.com/wagoodman/dive/filetree"
+
+// Layer is the read-only view of a single image layer that every Analyzer
+// implementation (Docker, OCI image layout, registry, archive) exposes
+// identically to the rest of dive.
+type Layer interface {
+	TarId() string
+	Id() string
+	Index() int
+	Size() uint64
+	Tree() *filetree.FileTree
+	Command() string
+	ShortId() string
+	String() string
+}
+
+// AnalysisResult is the complete, source-agnostic result of analyzing an
+// image, regardless of which Analyzer produced it.
+type AnalysisResult struct {
+	Layers            []Layer
+	RefTrees          []*filetree.FileTree
+	Efficiency        float64
+	Inefficiencies    filetree.EfficiencySlice
+	SizeBytes         uint64
+	UserSizeByes      uint64
+	WastedBytes       uint64
+	WastedUserPercent float64
+	// Warnings surfaces non-fatal issues encountered while assembling the
+	// image, e.g. a foreign layer that could not be fetched, so the TUI can
+	// display them.
+	Warnings []string
+}
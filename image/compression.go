@@ -0,0 +1,46 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// magic byte prefixes used to sniff the compression of a layer blob, see
+// https://www.garykessler.net/library/file_sigs.html
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressReader wraps r in a decompressing io.ReadCloser based on the
+// compression format sniffed from its leading magic bytes. A stream that is
+// neither gzip nor zstd is assumed to already be a plain tar and is passed
+// through unmodified. This mirrors the approach moby/containers use to
+// normalize layer input (archive.DecompressStream) so that Parse doesn't
+// need to know in advance whether a layer was shipped compressed.
+func decompressReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+
+	magic, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(buffered)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return zstdReader.IOReadCloser(), nil
+	default:
+		return ioutil.NopCloser(buffered), nil
+	}
+}
@@ -0,0 +1,94 @@
+package image
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompressReader(t *testing.T) {
+	want := []byte("hello layer contents")
+
+	tests := []struct {
+		name  string
+		input func(t *testing.T) []byte
+	}{
+		{
+			name: "gzip",
+			input: func(t *testing.T) []byte {
+				var buf bytes.Buffer
+				gz := gzip.NewWriter(&buf)
+				if _, err := gz.Write(want); err != nil {
+					t.Fatal(err)
+				}
+				if err := gz.Close(); err != nil {
+					t.Fatal(err)
+				}
+				return buf.Bytes()
+			},
+		},
+		{
+			name: "zstd",
+			input: func(t *testing.T) []byte {
+				var buf bytes.Buffer
+				enc, err := zstd.NewWriter(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := enc.Write(want); err != nil {
+					t.Fatal(err)
+				}
+				if err := enc.Close(); err != nil {
+					t.Fatal(err)
+				}
+				return buf.Bytes()
+			},
+		},
+		{
+			name: "plain tar-like passthrough",
+			input: func(t *testing.T) []byte {
+				return want
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := decompressReader(bytes.NewReader(tt.input(t)))
+			if err != nil {
+				t.Fatalf("decompressReader() error = %v", err)
+			}
+			defer reader.Close()
+
+			got, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("reading decompressed stream: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDecompressReaderShortInput(t *testing.T) {
+	// fewer than 4 bytes shouldn't error out while peeking magic bytes.
+	input := []byte{0x01, 0x02}
+
+	reader, err := decompressReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("decompressReader() error = %v", err)
+	}
+	defer reader.Close()
+
+	got, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading decompressed stream: %v", err)
+	}
+	if !bytes.Equal(got, input) {
+		t.Errorf("got %v, want %v", got, input)
+	}
+}
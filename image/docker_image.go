@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"strings"
 
 	"github.com/docker/docker/client"
@@ -22,6 +23,9 @@ type dockerImageAnalyzer struct {
 	trees     []*filetree.FileTree
 	layerMap  map[string]*filetree.FileTree
 	layers    []*dockerLayer
+	// Warnings collects non-fatal issues found while assembling the image,
+	// e.g. a foreign layer that could not be fetched.
+	Warnings []string
 }
 
 func newDockerImageAnalyzer(imageId string) dockerImageAnalyzer {
@@ -137,13 +141,15 @@ func (image *dockerImageAnalyzer) Parse(tarFile io.ReadCloser) error {
 			continue
 		}
 
-		if strings.HasSuffix(name, "layer.tar") {
+		if isLayerTarName(name) {
 			currentLayer++
+
+			layerReader, err := decompressReader(tarReader)
 			if err != nil {
 				return err
 			}
-			layerReader := tar.NewReader(tarReader)
-			tree, err := processLayerTar(name, currentLayer, layerReader)
+			tree, err := processLayerTar(name, currentLayer, tar.NewReader(layerReader))
+			layerReader.Close()
 			if err != nil {
 				return err
 			}
@@ -161,22 +167,129 @@ func (image *dockerImageAnalyzer) Parse(tarFile io.ReadCloser) error {
 }
 
 func (image *dockerImageAnalyzer) Analyze() (*AnalysisResult, error) {
-	image.trees = make([]*filetree.FileTree, 0)
-
 	manifest := newDockerImageManifest(image.jsonFiles["manifest.json"])
 	config := newDockerImageConfig(image.jsonFiles[manifest.ConfigPath])
+	foreignSources := parseForeignLayerSources(image.jsonFiles["manifest.json"])
 
-	// build the content tree
+	image.trees = make([]*filetree.FileTree, 0)
+	diffIdx := 0
 	for _, treeName := range manifest.LayerTarPaths {
-		image.trees = append(image.trees, image.layerMap[treeName])
+		tree, ok := image.layerMap[treeName]
+		if !ok {
+			var diffID string
+			if diffIdx < len(config.RootFs.DiffIds) {
+				diffID = config.RootFs.DiffIds[diffIdx]
+			}
+			tree = image.resolveForeignLayer(treeName, diffID, uint(diffIdx+1), foreignSources[diffID])
+		}
+		image.trees = append(image.trees, tree)
+		diffIdx++
+	}
+
+	image.layers = assembleLayers(image.trees, config, manifest.LayerTarPaths)
+
+	return assembleAnalysisResult(image.trees, image.layers, image.Warnings), nil
+}
+
+// foreignLayerDescriptor describes a non-distributable ("foreign") layer,
+// e.g. a Windows base layer shipped with media type
+// application/vnd.docker.image.rootfs.foreign.diff.tar.gzip, as recorded in
+// manifest.json's optional "LayerSources" map (keyed by the layer's diffID).
+// docker save never writes a layer.tar for these, since their content must
+// instead be fetched from the URLs the registry advertised for them.
+type foreignLayerDescriptor struct {
+	MediaType string   `json:"mediaType"`
+	Size      int64    `json:"size"`
+	Digest    string   `json:"digest"`
+	URLs      []string `json:"urls"`
+}
+
+// parseForeignLayerSources re-reads the raw manifest.json bytes looking for
+// the optional "LayerSources" field, which is only populated when the image
+// contains foreign layers.
+func parseForeignLayerSources(manifestBytes []byte) map[string]foreignLayerDescriptor {
+	var manifests []struct {
+		LayerSources map[string]foreignLayerDescriptor `json:"LayerSources"`
+	}
+	if err := json.Unmarshal(manifestBytes, &manifests); err != nil || len(manifests) == 0 {
+		return nil
+	}
+	return manifests[0].LayerSources
+}
+
+// resolveForeignLayer builds the tree for a layer whose content docker save
+// did not materialize. It fetches the layer over HTTP when a source URL is
+// known; when there is no URL or every fetch fails it falls back to an empty
+// placeholder and records a warning, rather than letting the caller nil-deref
+// on a missing tree. A successful fetch records no warning.
+func (image *dockerImageAnalyzer) resolveForeignLayer(treeName, diffID string, layerIdx uint, source foreignLayerDescriptor) *filetree.FileTree {
+	if len(source.URLs) > 0 {
+		tree, err := fetchForeignLayerTree(treeName, layerIdx, source)
+		if err == nil {
+			return tree
+		}
+		image.Warnings = append(image.Warnings, fmt.Sprintf("layer %s (%s): failed to fetch foreign layer, showing as empty: %v", treeName, diffID, err))
+	} else {
+		image.Warnings = append(image.Warnings, fmt.Sprintf("layer %s (%s): foreign/non-distributable layer has no content in this archive", treeName, diffID))
+	}
+
+	tree := filetree.NewFileTree()
+	tree.Name = treeName
+	return tree
+}
+
+// fetchForeignLayerTree downloads a foreign layer directly from its
+// advertised source URLs and processes it exactly like a local layer.tar
+// entry, trying each URL in turn until one succeeds.
+func fetchForeignLayerTree(treeName string, layerIdx uint, source foreignLayerDescriptor) (*filetree.FileTree, error) {
+	var lastErr error
+	for _, url := range source.URLs {
+		resp, err := http.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+			continue
+		}
+
+		reader, err := decompressReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			lastErr = err
+			continue
+		}
+
+		tree, err := processLayerTar(treeName, layerIdx, tar.NewReader(reader))
+		reader.Close()
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return tree, nil
 	}
 
-	// build the layers array
-	image.layers = make([]*dockerLayer, len(image.trees))
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no source URLs available for foreign layer")
+	}
+	return nil, lastErr
+}
+
+// assembleLayers pairs each layer tree with its corresponding history entry
+// from the image config, producing the chronologically ordered layer array
+// shared by every Analyzer implementation.
+//
+// note that the image config stores history in reverse chronological order, so iterate backwards through layers
+// as you iterate chronologically through history (ignoring history items that have no layer contents)
+func assembleLayers(trees []*filetree.FileTree, config dockerImageConfig, tarPaths []string) []*dockerLayer {
+	layers := make([]*dockerLayer, len(trees))
 
-	// note that the image config stores images in reverse chronological order, so iterate backwards through layers
-	// as you iterate chronologically through history (ignoring history items that have no layer contents)
-	layerIdx := len(image.trees) - 1
+	layerIdx := len(trees) - 1
 	tarPathIdx := 0
 	for idx := 0; idx < len(config.History); idx++ {
 		// ignore empty layers, we are only observing layers with content
@@ -184,25 +297,34 @@ func (image *dockerImageAnalyzer) Analyze() (*AnalysisResult, error) {
 			continue
 		}
 
-		tree := image.trees[(len(image.trees)-1)-layerIdx]
+		tree := trees[(len(trees)-1)-layerIdx]
 		config.History[idx].Size = uint64(tree.FileSize)
 
-		image.layers[layerIdx] = &dockerLayer{
+		layers[layerIdx] = &dockerLayer{
 			history: config.History[idx],
 			index:   layerIdx,
-			tree:    image.trees[layerIdx],
-			tarPath: manifest.LayerTarPaths[tarPathIdx],
+			tree:    trees[layerIdx],
+			tarPath: tarPaths[tarPathIdx],
 		}
 
 		layerIdx--
 		tarPathIdx++
 	}
 
-	efficiency, inefficiencies := filetree.Efficiency(image.trees)
+	return layers
+}
+
+// assembleAnalysisResult computes efficiency and size statistics for the
+// given trees/layers and packages them into the result returned by Analyze.
+// warnings surfaces any non-fatal issues encountered while assembling the
+// trees (e.g. a foreign layer that couldn't be fetched) so the TUI can
+// display them.
+func assembleAnalysisResult(trees []*filetree.FileTree, dockerLayers []*dockerLayer, warnings []string) *AnalysisResult {
+	efficiency, inefficiencies := filetree.Efficiency(trees)
 
 	var sizeBytes, userSizeBytes uint64
-	layers := make([]Layer, len(image.layers))
-	for i, v := range image.layers {
+	layers := make([]Layer, len(dockerLayers))
+	for i, v := range dockerLayers {
 		layers[i] = v
 		sizeBytes += v.Size()
 		if i != 0 {
@@ -218,14 +340,30 @@ func (image *dockerImageAnalyzer) Analyze() (*AnalysisResult, error) {
 
 	return &AnalysisResult{
 		Layers:            layers,
-		RefTrees:          image.trees,
+		RefTrees:          trees,
 		Efficiency:        efficiency,
 		UserSizeByes:      userSizeBytes,
 		SizeBytes:         sizeBytes,
 		WastedBytes:       wastedBytes,
 		WastedUserPercent: float64(float64(wastedBytes) / float64(userSizeBytes)),
 		Inefficiencies:    inefficiencies,
-	}, nil
+		Warnings:          warnings,
+	}
+}
+
+// layerTarSuffixes are the file extensions a tar entry may carry when it
+// holds a layer's filesystem diff, compressed or not.
+var layerTarSuffixes = []string{"layer.tar", "layer.tar.gz", "layer.tgz", "layer.tar.zst"}
+
+// isLayerTarName reports whether name looks like a (possibly compressed)
+// layer diff entry rather than metadata such as manifest.json.
+func isLayerTarName(name string) bool {
+	for _, suffix := range layerTarSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
 }
 
 // processLayerTar iterates through the files in the provided tar archive and
@@ -0,0 +1,121 @@
+package image
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// isOciArchive reports whether the tar at path packs an OCI image layout
+// (identified by its oci-layout marker file) rather than a Docker save tar,
+// as produced by e.g. `skopeo copy ... oci-archive:img.tar` or buildah/kaniko.
+func isOciArchive(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			return false
+		}
+		if filepath.Base(header.Name) == "oci-layout" {
+			return true
+		}
+	}
+}
+
+// ociArchiveAnalyzer reads an OCI image layout packed into a single tar
+// file (an "oci-archive") by extracting it to a temporary directory and
+// delegating to the regular directory-based ociImageAnalyzer.
+type ociArchiveAnalyzer struct {
+	path     string
+	delegate Analyzer
+}
+
+// newOciArchiveAnalyzer builds an Analyzer for a single-file OCI archive at path.
+func newOciArchiveAnalyzer(path string) Analyzer {
+	return &ociArchiveAnalyzer{path: path}
+}
+
+// Fetch extracts the archive to a temporary directory and hands the rest
+// of the work off to an ociImageAnalyzer rooted there.
+func (image *ociArchiveAnalyzer) Fetch() (io.ReadCloser, error) {
+	dir, err := ioutil.TempDir("", "dive-oci-archive-")
+	if err != nil {
+		return nil, err
+	}
+
+	tarFile, err := os.Open(image.path)
+	if err != nil {
+		return nil, err
+	}
+	defer tarFile.Close()
+
+	if err := extractTar(dir, tarFile); err != nil {
+		return nil, err
+	}
+
+	image.delegate = newOciImageAnalyzer(dir)
+	return image.delegate.Fetch()
+}
+
+func (image *ociArchiveAnalyzer) Parse(r io.ReadCloser) error {
+	return image.delegate.Parse(r)
+}
+
+func (image *ociArchiveAnalyzer) Analyze() (*AnalysisResult, error) {
+	return image.delegate.Analyze()
+}
+
+// extractTar writes the contents of tarFile into dir, preserving its
+// directory structure, so a single-file OCI archive can be handed to the
+// directory-based OCI image layout analyzer unchanged.
+func extractTar(dir string, tarFile io.Reader) error {
+	reader := tar.NewReader(tarFile)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, reader); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			// best effort: a broken symlink shouldn't fail the whole extraction
+			_ = os.Symlink(header.Linkname, target)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package image
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wagoodman/dive/filetree"
+)
+
+// ociRefNameAnnotation is the well-known OCI annotation used to tag a
+// manifest entry in index.json with a human readable reference name.
+const ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+// ociDescriptor is a content-addressable pointer to a blob, as used by both
+// the image index and the image manifest.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	URLs        []string          `json:"urls,omitempty"`
+}
+
+// ociIndex is the top level `index.json` of an OCI Image Layout.
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest is the image manifest referenced from the index.
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+// ociImageAnalyzer reads an image directly out of an OCI Image Layout
+// directory (https://github.com/opencontainers/image-spec/blob/main/image-layout.md),
+// resolving the manifest, config and layer blobs from `blobs/<algo>/<digest>`
+// without ever going through a Docker daemon.
+type ociImageAnalyzer struct {
+	path      string
+	ref       string
+	jsonFiles map[string][]byte
+	trees     []*filetree.FileTree
+	layerMap  map[string]*filetree.FileTree
+	layers    []*dockerLayer
+	manifest  ociManifest
+}
+
+// newOciImageAnalyzer builds an analyzer for the OCI image layout at path.
+// An optional reference (tag or digest) to select within index.json may be
+// appended with a `#`, e.g. "/path/to/layout#v1.2.3".
+func newOciImageAnalyzer(target string) *ociImageAnalyzer {
+	path := target
+	ref := ""
+	if idx := strings.LastIndex(target, "#"); idx != -1 {
+		path = target[:idx]
+		ref = target[idx+1:]
+	}
+
+	return &ociImageAnalyzer{
+		path:      path,
+		ref:       ref,
+		jsonFiles: make(map[string][]byte),
+		layerMap:  make(map[string]*filetree.FileTree),
+	}
+}
+
+// Fetch confirms the target is an OCI image layout (by way of its
+// `oci-layout` marker file) and returns its index.json for Parse to consume.
+func (image *ociImageAnalyzer) Fetch() (io.ReadCloser, error) {
+	if _, err := os.Stat(filepath.Join(image.path, "oci-layout")); err != nil {
+		return nil, fmt.Errorf("'%s' is not an OCI image layout: %v", image.path, err)
+	}
+
+	return os.Open(filepath.Join(image.path, "index.json"))
+}
+
+// Parse reads index.json, resolves the target manifest, then streams every
+// referenced layer blob through processLayerTar the same way the Docker
+// analyzer does for a `layer.tar` entry.
+func (image *ociImageAnalyzer) Parse(indexFile io.ReadCloser) error {
+	defer indexFile.Close()
+
+	indexBytes, err := ioutil.ReadAll(indexFile)
+	if err != nil {
+		return err
+	}
+
+	var index ociIndex
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return err
+	}
+
+	descriptor, err := image.resolveManifest(index)
+	if err != nil {
+		return err
+	}
+
+	manifestBytes, err := image.readBlob(descriptor.Digest)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(manifestBytes, &image.manifest); err != nil {
+		return err
+	}
+
+	for idx, layerDesc := range image.manifest.Layers {
+		tree, err := image.parseLayerBlob(layerDesc, uint(idx+1))
+		if err != nil {
+			return err
+		}
+		image.layerMap[layerDesc.Digest] = tree
+	}
+
+	configBytes, err := image.readBlob(image.manifest.Config.Digest)
+	if err != nil {
+		return err
+	}
+	image.jsonFiles["config.json"] = configBytes
+
+	return nil
+}
+
+// Analyze builds the AnalysisResult from the manifest and layers parsed above.
+func (image *ociImageAnalyzer) Analyze() (*AnalysisResult, error) {
+	config := newDockerImageConfig(image.jsonFiles["config.json"])
+
+	image.trees = make([]*filetree.FileTree, 0)
+	tarPaths := make([]string, len(image.manifest.Layers))
+	for idx, layerDesc := range image.manifest.Layers {
+		image.trees = append(image.trees, image.layerMap[layerDesc.Digest])
+		tarPaths[idx] = layerDesc.Digest
+	}
+
+	image.layers = assembleLayers(image.trees, config, tarPaths)
+
+	return assembleAnalysisResult(image.trees, image.layers, nil), nil
+}
+
+// resolveManifest picks the manifest descriptor matching image.ref (by tag
+// annotation or digest), falling back to the sole entry when there is no
+// ambiguity.
+func (image *ociImageAnalyzer) resolveManifest(index ociIndex) (ociDescriptor, error) {
+	if image.ref != "" {
+		for _, m := range index.Manifests {
+			if m.Digest == image.ref || m.Annotations[ociRefNameAnnotation] == image.ref {
+				return m, nil
+			}
+		}
+		return ociDescriptor{}, fmt.Errorf("no manifest matching '%s' found in %s/index.json", image.ref, image.path)
+	}
+
+	if len(index.Manifests) == 1 {
+		return index.Manifests[0], nil
+	}
+
+	return ociDescriptor{}, fmt.Errorf("%s/index.json has %d manifests, a tag or digest reference is required", image.path, len(index.Manifests))
+}
+
+// parseLayerBlob decompresses (if necessary) and processes a single layer
+// blob referenced by descriptor.
+func (image *ociImageAnalyzer) parseLayerBlob(descriptor ociDescriptor, layerIdx uint) (*filetree.FileTree, error) {
+	blob, err := image.openBlob(descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	reader, err := decompressReader(blob)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return processLayerTar(descriptor.Digest, layerIdx, tar.NewReader(reader))
+}
+
+// blobPath resolves a digest of the form "<algo>:<hex>" to its on-disk path
+// under blobs/<algo>/<hex>.
+func (image *ociImageAnalyzer) blobPath(digest string) (string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid OCI digest '%s'", digest)
+	}
+	return filepath.Join(image.path, "blobs", parts[0], parts[1]), nil
+}
+
+func (image *ociImageAnalyzer) openBlob(digest string) (*os.File, error) {
+	path, err := image.blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (image *ociImageAnalyzer) readBlob(digest string) ([]byte, error) {
+	blob, err := image.openBlob(digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	return ioutil.ReadAll(blob)
+}
@@ -0,0 +1,110 @@
+package image
+
+import "testing"
+
+func TestOciImageAnalyzerBlobPath(t *testing.T) {
+	analyzer := newOciImageAnalyzer("/layouts/demo")
+
+	tests := []struct {
+		name    string
+		digest  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "valid sha256 digest",
+			digest: "sha256:abcdef0123456789",
+			want:   "/layouts/demo/blobs/sha256/abcdef0123456789",
+		},
+		{
+			name:    "missing algorithm separator",
+			digest:  "abcdef0123456789",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := analyzer.blobPath(tt.digest)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got path %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("blobPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOciImageAnalyzerResolveManifest(t *testing.T) {
+	manifests := []ociDescriptor{
+		{Digest: "sha256:aaa", Annotations: map[string]string{ociRefNameAnnotation: "v1"}},
+		{Digest: "sha256:bbb", Annotations: map[string]string{ociRefNameAnnotation: "v2"}},
+	}
+
+	tests := []struct {
+		name       string
+		ref        string
+		manifests  []ociDescriptor
+		wantDigest string
+		wantErr    bool
+	}{
+		{
+			name:       "resolve by tag annotation",
+			ref:        "v2",
+			manifests:  manifests,
+			wantDigest: "sha256:bbb",
+		},
+		{
+			name:       "resolve by digest",
+			ref:        "sha256:aaa",
+			manifests:  manifests,
+			wantDigest: "sha256:aaa",
+		},
+		{
+			name:      "unknown ref",
+			ref:       "missing",
+			manifests: manifests,
+			wantErr:   true,
+		},
+		{
+			name:       "single manifest, no ref needed",
+			ref:        "",
+			manifests:  manifests[:1],
+			wantDigest: "sha256:aaa",
+		},
+		{
+			name:      "ambiguous manifests, no ref given",
+			ref:       "",
+			manifests: manifests,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := newOciImageAnalyzer("/layouts/demo")
+			analyzer.ref = tt.ref
+
+			got, err := analyzer.resolveManifest(ociIndex{Manifests: tt.manifests})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got descriptor %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveManifest() error = %v", err)
+			}
+			if got.Digest != tt.wantDigest {
+				t.Errorf("got digest %q, want %q", got.Digest, tt.wantDigest)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/wagoodman/dive/filetree"
+)
+
+// registryImageAnalyzer resolves and streams an image's layers straight
+// from an OCI Distribution / Docker Registry v2 endpoint (Docker Hub, ECR,
+// GHCR, ...), without requiring a local docker daemon or a prior
+// `docker pull`.
+type registryImageAnalyzer struct {
+	ref       string
+	keychain  authn.Keychain
+	cacheDir  string
+	image     v1.Image
+	jsonFiles map[string][]byte
+	trees     []*filetree.FileTree
+	layerMap  map[string]*filetree.FileTree
+	layers    []*dockerLayer
+}
+
+// NewRegistryAnalyzer builds an Analyzer that talks directly to the
+// registry serving ref (e.g. "docker.io/library/alpine:3.18" or
+// "ghcr.io/foo/bar@sha256:..."), authenticating with keychain. A nil
+// keychain falls back to authn.DefaultKeychain, which reads
+// ~/.docker/config.json and the standard *_AUTH / DOCKER_* env vars.
+func NewRegistryAnalyzer(ref string, keychain authn.Keychain) Analyzer {
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+
+	return &registryImageAnalyzer{
+		ref:       ref,
+		keychain:  keychain,
+		cacheDir:  registryBlobCacheDir(),
+		jsonFiles: make(map[string][]byte),
+		layerMap:  make(map[string]*filetree.FileTree),
+	}
+}
+
+// registryBlobCacheDir returns the on-disk location used to cache
+// downloaded layer blobs, keyed by digest, so re-analysis of an image
+// already seen doesn't re-fetch it from the network.
+func registryBlobCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "dive", "registry-blobs")
+}
+
+// Fetch resolves ref against the registry and records the resulting
+// v1.Image for Parse/Analyze to read from. The returned stream carries the
+// image's raw manifest purely so Fetch/Parse keep the same shape as every
+// other Analyzer; all of the real state lives on the analyzer itself.
+func (image *registryImageAnalyzer) Fetch() (io.ReadCloser, error) {
+	ref, err := name.ParseReference(image.ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry reference '%s': %v", image.ref, err)
+	}
+
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(image.keychain))
+	if err != nil {
+		return nil, err
+	}
+	image.image = img
+
+	rawManifest, err := img.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(rawManifest)), nil
+}
+
+// Parse reads the image config and streams every layer blob (decompressing
+// and caching it as needed) through the same processLayerTar pipeline used
+// by every other Analyzer.
+func (image *registryImageAnalyzer) Parse(manifest io.ReadCloser) error {
+	defer manifest.Close()
+
+	if image.image == nil {
+		return fmt.Errorf("registry image '%s' has not been fetched", image.ref)
+	}
+
+	config, err := image.image.ConfigFile()
+	if err != nil {
+		return err
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	image.jsonFiles["config.json"] = configBytes
+
+	layers, err := image.image.Layers()
+	if err != nil {
+		return err
+	}
+
+	for idx, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return err
+		}
+
+		tree, err := image.parseLayer(layer, digest, uint(idx+1))
+		if err != nil {
+			return err
+		}
+		image.layerMap[digest.String()] = tree
+	}
+
+	return nil
+}
+
+// parseLayer fetches (or reuses the on-disk cache of) a single layer blob
+// and processes it into a FileTree.
+func (image *registryImageAnalyzer) parseLayer(layer v1.Layer, digest v1.Hash, layerIdx uint) (*filetree.FileTree, error) {
+	blob, err := image.cachedLayerBlob(layer, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	reader, err := decompressReader(blob)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return processLayerTar(digest.String(), layerIdx, tar.NewReader(reader))
+}
+
+// cacheKey turns a digest ("sha256:abcdef...") into a path-safe filename;
+// ":" is not legal in a path component on Windows.
+func cacheKey(digest v1.Hash) string {
+	return digest.Algorithm + "-" + digest.Hex
+}
+
+// cachedLayerBlob returns the compressed layer blob for digest, downloading
+// it from the registry and caching it on disk the first time it's seen.
+func (image *registryImageAnalyzer) cachedLayerBlob(layer v1.Layer, digest v1.Hash) (io.ReadCloser, error) {
+	path := filepath.Join(image.cacheDir, cacheKey(digest))
+
+	if cached, err := os.Open(path); err == nil {
+		return cached, nil
+	}
+
+	compressed, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer compressed.Close()
+
+	if err := os.MkdirAll(image.cacheDir, 0755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempFile(image.cacheDir, cacheKey(digest)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, compressed); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+// Analyze builds the AnalysisResult from the config and layers parsed above.
+func (image *registryImageAnalyzer) Analyze() (*AnalysisResult, error) {
+	config := newDockerImageConfig(image.jsonFiles["config.json"])
+
+	layers, err := image.image.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	image.trees = make([]*filetree.FileTree, 0, len(layers))
+	tarPaths := make([]string, len(layers))
+	for idx, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, err
+		}
+		image.trees = append(image.trees, image.layerMap[digest.String()])
+		tarPaths[idx] = digest.String()
+	}
+
+	image.layers = assembleLayers(image.trees, config, tarPaths)
+
+	return assembleAnalysisResult(image.trees, image.layers, nil), nil
+}
@@ -0,0 +1,39 @@
+package image
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		digest v1.Hash
+		want   string
+	}{
+		{
+			name:   "sha256 digest",
+			digest: v1.Hash{Algorithm: "sha256", Hex: "abcdef0123456789"},
+			want:   "sha256-abcdef0123456789",
+		},
+		{
+			name:   "sha512 digest",
+			digest: v1.Hash{Algorithm: "sha512", Hex: "0123abcdef"},
+			want:   "sha512-0123abcdef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cacheKey(tt.digest)
+			if got != tt.want {
+				t.Errorf("cacheKey() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(got, ":") {
+				t.Errorf("cacheKey() = %q contains a path-unsafe ':'", got)
+			}
+		})
+	}
+}
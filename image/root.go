@@ -1,8 +1,78 @@
 package image
 
+import (
+	"io"
+	"strings"
+)
+
 type AnalyzerFactory func(string) Analyzer
 
-func GetAnalyzer(imageID string) dockerImageAnalyzer {
-	// todo: add ability to have multiple image formats... for the meantime only use docker
-	return newDockerImageAnalyzer(imageID)
+// Analyzer is the common interface implemented by every supported image
+// source (docker daemon, OCI image layout, registry, on-disk archive, ...)
+// so the rest of dive can drive them identically.
+type Analyzer interface {
+	// Fetch retrieves the raw image content, returning a stream for Parse to consume.
+	Fetch() (io.ReadCloser, error)
+	// Parse reads the stream returned by Fetch and builds the internal layer/tree state.
+	Parse(io.ReadCloser) error
+	// Analyze produces the final AnalysisResult from the parsed state.
+	Analyze() (*AnalysisResult, error)
+}
+
+const (
+	ociLayoutPrefix     = "oci-layout://"
+	ociArchivePrefix    = "oci-archive://"
+	ociArchiveAlias     = "oci-archive:"
+	dockerArchivePrefix = "docker-archive://"
+	dockerArchiveAlias  = "docker-archive:"
+	dockerDaemonPrefix  = "docker-daemon://"
+	registryPrefix      = "registry://"
+)
+
+// archivePathSuffixes are the file extensions that mark a bare reference as
+// an on-disk image archive rather than a docker daemon image name.
+var archivePathSuffixes = []string{".tar", ".tar.gz", ".tgz"}
+
+// looksLikeArchivePath reports whether ref looks like a filesystem path to
+// an image archive (e.g. "./foo.tar", "../build/img.tar") rather than a
+// docker image name/ID.
+func looksLikeArchivePath(ref string) bool {
+	if strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || strings.HasPrefix(ref, "/") {
+		return true
+	}
+	for _, suffix := range archivePathSuffixes {
+		if strings.HasSuffix(ref, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAnalyzer returns the Analyzer implementation responsible for the given
+// image reference. The transport is sniffed from the reference's prefix,
+// following the same convention as containers/image's transport selection,
+// and falls back to the docker daemon for a bare image name/ID.
+func GetAnalyzer(imageID string) Analyzer {
+	switch {
+	case strings.HasPrefix(imageID, ociLayoutPrefix):
+		return newOciImageAnalyzer(strings.TrimPrefix(imageID, ociLayoutPrefix))
+	case strings.HasPrefix(imageID, ociArchivePrefix):
+		return newOciArchiveAnalyzer(strings.TrimPrefix(imageID, ociArchivePrefix))
+	case strings.HasPrefix(imageID, ociArchiveAlias):
+		return newOciArchiveAnalyzer(strings.TrimPrefix(imageID, ociArchiveAlias))
+	case strings.HasPrefix(imageID, registryPrefix):
+		return NewRegistryAnalyzer(strings.TrimPrefix(imageID, registryPrefix), nil)
+	case strings.HasPrefix(imageID, dockerArchivePrefix):
+		return NewArchiveAnalyzer(strings.TrimPrefix(imageID, dockerArchivePrefix))
+	case strings.HasPrefix(imageID, dockerArchiveAlias):
+		return NewArchiveAnalyzer(strings.TrimPrefix(imageID, dockerArchiveAlias))
+	case strings.HasPrefix(imageID, dockerDaemonPrefix):
+		analyzer := newDockerImageAnalyzer(strings.TrimPrefix(imageID, dockerDaemonPrefix))
+		return &analyzer
+	case looksLikeArchivePath(imageID):
+		return NewArchiveAnalyzer(imageID)
+	default:
+		analyzer := newDockerImageAnalyzer(imageID)
+		return &analyzer
+	}
 }